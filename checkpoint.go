@@ -0,0 +1,150 @@
+package linescanner
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+var ErrReverseSeekLine = errors.New("SeekLine is not supported by a reverse Scanner")
+
+// Checkpoint is an opaque snapshot of a Scanner's position and buffered
+// state, obtained with Scanner.Checkpoint and resumed with
+// Scanner.Restore without re-reading any input already consumed.
+type Checkpoint struct {
+	reverse bool
+
+	bufferLineStartPos int
+	readerPos          int
+	readerLineStartPos int
+
+	bufferLineEndPos int
+	readerLineEndPos int
+
+	endOfFile       bool
+	beginningOfFile bool
+	endOfScan       bool
+
+	buffer []byte
+}
+
+// Checkpoint captures the Scanner's current position, including any
+// buffered but not yet consumed input, so a later call to Restore can
+// resume scanning from exactly this point without re-reading the reader.
+func (s *Scanner) Checkpoint() Checkpoint {
+	buffer := make([]byte, len(s.buffer))
+	copy(buffer, s.buffer)
+	return Checkpoint{
+		reverse:            s.reverse,
+		bufferLineStartPos: s.bufferLineStartPos,
+		readerPos:          s.readerPos,
+		readerLineStartPos: s.readerLineStartPos,
+		bufferLineEndPos:   s.bufferLineEndPos,
+		readerLineEndPos:   s.readerLineEndPos,
+		endOfFile:          s.endOfFile,
+		beginningOfFile:    s.beginningOfFile,
+		endOfScan:          s.endOfScan,
+		buffer:             buffer,
+	}
+}
+
+// Restore resets the Scanner to the position captured by cp. It returns
+// ErrGreaterBufferSize if cp's buffer no longer fits the Scanner's
+// buffer capacity, for instance after moving a Checkpoint to a Scanner
+// constructed with a smaller buffer size.
+func (s *Scanner) Restore(cp Checkpoint) error {
+	if len(cp.buffer) > cap(s.buffer) {
+		return ErrGreaterBufferSize
+	}
+	s.buffer = append(s.buffer[:0], cp.buffer...)
+	s.reverse = cp.reverse
+	s.bufferLineStartPos = cp.bufferLineStartPos
+	s.readerPos = cp.readerPos
+	s.readerLineStartPos = cp.readerLineStartPos
+	s.bufferLineEndPos = cp.bufferLineEndPos
+	s.readerLineEndPos = cp.readerLineEndPos
+	s.endOfFile = cp.endOfFile
+	s.beginningOfFile = cp.beginningOfFile
+	s.endOfScan = cp.endOfScan
+	s.token = nil
+	s.scanErr = nil
+	return nil
+}
+
+// Reset reconfigures the Scanner to read reader starting at position,
+// reusing the existing chunk and buffer allocations. Direction (forward
+// or reverse) and the split function are left unchanged.
+func (s *Scanner) Reset(reader io.ReaderAt, position int) {
+	s.reader = reader
+	s.buffer = s.buffer[:0]
+	s.endOfFile = false
+	s.beginningOfFile = false
+	s.endOfScan = false
+	s.token = nil
+	s.scanErr = nil
+	if s.reverse {
+		s.bufferLineEndPos = 0
+		s.readerPos = position
+		s.readerLineEndPos = position
+	} else {
+		s.bufferLineStartPos = 0
+		s.readerPos = position
+		s.readerLineStartPos = position
+	}
+}
+
+// SeekLine repositions a forward Scanner to the start of the next
+// complete line at or after offset, discarding any partial line that
+// offset falls in the middle of. offset 0 is always the start of a line,
+// so it is used as-is. SeekLine returns ErrReverseSeekLine for a reverse
+// Scanner.
+func (s *Scanner) SeekLine(offset int64) error {
+	if s.reverse {
+		return ErrReverseSeekLine
+	}
+	position := int(offset)
+	if offset != 0 {
+		aligned, err := alignToLineStart(s.reader, position)
+		if err != nil {
+			return err
+		}
+		position = aligned
+	}
+	s.Reset(s.reader, position)
+	return nil
+}
+
+// alignToLineStart returns offset unchanged if it already sits at the
+// start of a line, i.e. it is preceded by '\n', and otherwise the offset
+// just past the next '\n' at or after offset.
+func alignToLineStart(reader io.ReaderAt, offset int) (int, error) {
+	var prev [1]byte
+	n, err := reader.ReadAt(prev[:], int64(offset-1))
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	if n == 1 && prev[0] == '\n' {
+		return offset, nil
+	}
+	return seekNextLineFeed(reader, offset)
+}
+
+// seekNextLineFeed returns the offset just past the first '\n' at or
+// after from, or the offset where reader ends if none is found.
+func seekNextLineFeed(reader io.ReaderAt, from int) (int, error) {
+	buf := make([]byte, defaultChunkSize)
+	pos := from
+	for {
+		n, err := reader.ReadAt(buf, int64(pos))
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if idx := bytes.IndexByte(buf[:n], '\n'); idx >= 0 {
+			return pos + idx + 1, nil
+		}
+		pos += n
+		if err == io.EOF {
+			return pos, nil
+		}
+	}
+}
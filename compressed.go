@@ -0,0 +1,326 @@
+package linescanner
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Codec decodes a single member of a compressed stream starting from the
+// beginning of r, stopping at that member's end without consuming bytes
+// belonging to anything that follows it.
+//
+// GzipCodec and ZlibCodec ship here since both are in the standard
+// library; the standard library has no zstd decoder, so zstd support is
+// left to a caller-supplied Codec wrapping their decoder of choice.
+type Codec interface {
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	gr.Multistream(false) // stop at this member's end; we stitch members ourselves
+	return gr, nil
+}
+
+// GzipCodec decodes gzip-compressed streams.
+var GzipCodec Codec = gzipCodec{}
+
+type zlibCodec struct{}
+
+func (zlibCodec) NewReader(r io.Reader) (io.ReadCloser, error) { return zlib.NewReader(r) }
+
+// ZlibCodec decodes zlib-compressed streams.
+var ZlibCodec Codec = zlibCodec{}
+
+// resettableDecoder is implemented by decoders, such as *gzip.Reader,
+// that can be rewound onto a new source without reallocating. Decoders
+// that don't implement it still work with NewCompressed, just without
+// the pooling benefit.
+type resettableDecoder interface {
+	io.Reader
+	Reset(r io.Reader) error
+}
+
+// checkpoint records that decoding member by member from sourceOffset
+// reproduces the decompressed stream starting at decodedOffset. Every
+// member boundary is a valid checkpoint because a fresh Codec decoder can
+// always be opened there; nothing narrower than a member boundary is
+// indexed this way, since resuming mid-member from a bare source offset
+// would need the decoder's internal dictionary window, which Codec
+// doesn't expose. Reads that land inside an already-decoded member are
+// instead served by the liveDecoder cache below.
+type checkpoint struct {
+	decodedOffset int64
+	sourceOffset  int64
+}
+
+// maxLiveDecoders bounds how many in-progress decoders compressedReaderAt
+// keeps warm at once. Scanner reads sequentially, so one slot covers a
+// single scan; ScanParallel runs one Scanner per shard concurrently
+// against the same reader, so a handful of slots lets each shard keep its
+// own decoder warm instead of evicting the others' progress.
+const maxLiveDecoders = 8
+
+// liveDecoder is a decoder paused mid-stream at decodedOffset, ready to
+// resume decoding forward from there without re-opening its member.
+type liveDecoder struct {
+	decodedOffset      int64
+	memberSourceOffset int64
+	dec                io.Reader
+	section            *countingReader
+}
+
+// compressedReaderAt adapts a compressed io.ReaderAt into a plain
+// io.ReaderAt over its decompressed contents, which is what lets
+// NewCompressed hand the result straight to NewWithSize. It treats the
+// compressed stream as a sequence of one or more concatenated codec
+// members and indexes each member's start as it is discovered, so a cold
+// read resumes from the start of the member containing it rather than
+// from the start of the whole stream.
+//
+// That member-boundary index alone isn't enough: a single-member stream,
+// the common case for a plain .gz file, only ever gets the one checkpoint
+// at offset 0, so repeated cold reads would all re-inflate from the
+// start. compressedReaderAt avoids that by keeping the decoder from the
+// previous ReadAt paused rather than discarded, in a small liveDecoder
+// cache keyed by the decompressed offset it's sitting at; the next read
+// that continues from (or near) there resumes the paused decoder instead
+// of re-opening its member. Go's compress/gzip and compress/zlib don't
+// expose a decoder's internal dictionary window, so this is the only
+// resumption point available short of decoding from a member boundary;
+// it's sufficient for Scanner's always-sequential access pattern and for
+// localized access such as SeekLine, but a read that jumps far ahead
+// within a large single-member stream still re-inflates from the nearest
+// checkpoint.
+type compressedReaderAt struct {
+	source     io.ReaderAt
+	sourceSize int64
+	codec      Codec
+
+	mu          sync.Mutex
+	checkpoints []checkpoint
+	live        []*liveDecoder
+
+	decoders sync.Pool
+}
+
+func newCompressedReaderAt(source io.ReaderAt, sourceSize int64, codec Codec) *compressedReaderAt {
+	return &compressedReaderAt{
+		source:      source,
+		sourceSize:  sourceSize,
+		codec:       codec,
+		checkpoints: []checkpoint{{decodedOffset: 0, sourceOffset: 0}},
+	}
+}
+
+func (c *compressedReaderAt) nearestCheckpoint(offset int64) checkpoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := sort.Search(len(c.checkpoints), func(i int) bool {
+		return c.checkpoints[i].decodedOffset > offset
+	})
+	return c.checkpoints[i-1]
+}
+
+func (c *compressedReaderAt) addCheckpoint(cp checkpoint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cp.decodedOffset <= c.checkpoints[len(c.checkpoints)-1].decodedOffset {
+		return
+	}
+	c.checkpoints = append(c.checkpoints, cp)
+}
+
+// acquireLive removes and returns the cached live decoder best positioned
+// to serve a read at offset, i.e. the one paused closest to, but not
+// after, offset, so the caller discards as little as possible to reach
+// it. It reports false if no cached decoder is positioned at or before
+// offset.
+func (c *compressedReaderAt) acquireLive(offset int64) (*liveDecoder, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	best := -1
+	for i, ld := range c.live {
+		if ld.decodedOffset > offset {
+			continue
+		}
+		if best < 0 || ld.decodedOffset > c.live[best].decodedOffset {
+			best = i
+		}
+	}
+	if best < 0 {
+		return nil, false
+	}
+	ld := c.live[best]
+	c.live = append(c.live[:best], c.live[best+1:]...)
+	return ld, true
+}
+
+// storeLive parks dec so a later read can resume it, evicting the
+// least-recently-stored entry once the cache is full.
+func (c *compressedReaderAt) storeLive(ld *liveDecoder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.live) >= maxLiveDecoders {
+		c.releaseToPool(c.live[0].dec)
+		c.live = c.live[1:]
+	}
+	c.live = append(c.live, ld)
+}
+
+// releaseToPool returns a decoder to the pool for its memory to be
+// reused by a future fresh-member open; the decoder must not still be
+// relied on to resume decoding, since Reset repositions it.
+func (c *compressedReaderAt) releaseToPool(dec io.Reader) {
+	if resettable, ok := dec.(resettableDecoder); ok {
+		c.decoders.Put(resettable)
+	}
+}
+
+// countingReader tracks how many compressed bytes a decoder has consumed
+// from the underlying source, so the offset the next member starts at
+// can be computed once this member's decoder reaches its end. It also
+// implements ReadByte so gzip.Reader and flate.Reader use it directly
+// instead of wrapping it in their own bufio.Reader: that internal
+// buffering reads ahead past the end of the current member, which would
+// make n overshoot into the next member's bytes and corrupt the index.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	var b [1]byte
+	n, err := c.r.Read(b[:])
+	c.n += int64(n)
+	if n == 1 {
+		return b[0], nil
+	}
+	if err == nil {
+		err = io.ErrNoProgress
+	}
+	return 0, err
+}
+
+// acquireDecoder returns a decoder positioned at the start of the member
+// beginning at sourceOffset, preferring a pooled decoder reset onto the
+// new source over allocating a fresh one.
+func (c *compressedReaderAt) acquireDecoder(sourceOffset int64) (io.Reader, *countingReader, error) {
+	section := &countingReader{r: io.NewSectionReader(c.source, sourceOffset, c.sourceSize-sourceOffset)}
+	if pooled := c.decoders.Get(); pooled != nil {
+		if dec, ok := pooled.(resettableDecoder); ok {
+			if err := dec.Reset(section); err == nil {
+				// gzip.Reader.Reset turns multistream back on.
+				if ms, ok := dec.(interface{ Multistream(bool) }); ok {
+					ms.Multistream(false)
+				}
+				return dec, section, nil
+			}
+		}
+	}
+	dec, err := c.codec.NewReader(section)
+	if err != nil {
+		return nil, section, err
+	}
+	return dec, section, nil
+}
+
+// nextMember is called once the current member's decoder has reported
+// io.EOF. The exhausted decoder is returned to the pool, since a decoder
+// that has hit EOF has nothing left to resume. If compressed data remains
+// beyond it, that position is indexed and a decoder for the new member is
+// returned; otherwise it reports the overall stream has ended.
+func (c *compressedReaderAt) nextMember(decodedAt, memberSourceOffset int64, dec io.Reader, section *countingReader) (io.Reader, *countingReader, int64, error) {
+	c.releaseToPool(dec)
+	sourceOffset := memberSourceOffset + section.n
+	if sourceOffset >= c.sourceSize {
+		return nil, nil, 0, io.EOF
+	}
+	c.addCheckpoint(checkpoint{decodedOffset: decodedAt, sourceOffset: sourceOffset})
+	newDec, newSection, err := c.acquireDecoder(sourceOffset)
+	return newDec, newSection, sourceOffset, err
+}
+
+func (c *compressedReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	var dec io.Reader
+	var section *countingReader
+	var decodedAt, memberSourceOffset int64
+
+	if ld, ok := c.acquireLive(off); ok {
+		dec, section, decodedAt, memberSourceOffset = ld.dec, ld.section, ld.decodedOffset, ld.memberSourceOffset
+	} else {
+		cp := c.nearestCheckpoint(off)
+		var err error
+		dec, section, err = c.acquireDecoder(cp.sourceOffset)
+		if err != nil {
+			return 0, err
+		}
+		decodedAt, memberSourceOffset = cp.decodedOffset, cp.sourceOffset
+	}
+
+	for skip := off - decodedAt; skip > 0; skip = off - decodedAt {
+		m, err := io.CopyN(io.Discard, dec, skip)
+		decodedAt += m
+		if err == nil {
+			break
+		}
+		if err != io.EOF {
+			c.releaseToPool(dec)
+			return 0, err
+		}
+		dec, section, memberSourceOffset, err = c.nextMember(decodedAt, memberSourceOffset, dec, section)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n := 0
+	for n < len(p) {
+		m, rerr := dec.Read(p[n:])
+		n += m
+		decodedAt += int64(m)
+		if rerr == nil {
+			continue
+		}
+		if rerr != io.EOF {
+			c.releaseToPool(dec)
+			return n, rerr
+		}
+		var err error
+		dec, section, memberSourceOffset, err = c.nextMember(decodedAt, memberSourceOffset, dec, section)
+		if err != nil {
+			return n, err
+		}
+	}
+	c.storeLive(&liveDecoder{decodedOffset: decodedAt, memberSourceOffset: memberSourceOffset, dec: dec, section: section})
+	return n, nil
+}
+
+// NewCompressed returns a Scanner over data decoded by codec. size is the
+// length, in bytes, of the compressed stream reachable through reader;
+// position is a byte offset into the decompressed stream, matching the
+// position argument to New.
+func NewCompressed(reader io.ReaderAt, size int64, codec Codec, position int) *Scanner {
+	return NewCompressedWithSize(reader, size, codec, position, defaultChunkSize, defaultBufferSize)
+}
+
+func NewCompressedWithSize(reader io.ReaderAt, size int64, codec Codec, position int, chunkSize int, bufferSize int) *Scanner {
+	if size <= 0 {
+		panic(ErrInvalidSize)
+	}
+	return NewWithSize(newCompressedReaderAt(reader, size, codec), position, chunkSize, bufferSize)
+}
@@ -0,0 +1,88 @@
+package linescanner
+
+import "io"
+
+// Scan advances the Scanner to the next line, making it available through
+// Bytes or Text. It returns false when the scan stops, either by reaching
+// the end of the input or an error. After Scan returns false, Err reports
+// any error that occurred while scanning, except that if it was io.EOF,
+// Err will return nil.
+func (s *Scanner) Scan() bool {
+	if s.scanErr != nil {
+		return false
+	}
+	if s.reverse {
+		return s.scanReverse()
+	}
+	return s.scanForward()
+}
+
+func (s *Scanner) scanForward() bool {
+	token, err := s.nextToken()
+	if err != nil {
+		s.scanErr = err
+		return false
+	}
+	s.token = token
+	return true
+}
+
+func (s *Scanner) scanReverse() bool {
+	for {
+		if s.endOfScan {
+			return false
+		}
+		if s.bufferLineEndPos > 0 && s.buffer[s.bufferLineEndPos-1] == '\n' {
+			s.bufferLineEndPos-- // skip line feed position
+			s.readerLineEndPos--
+		}
+		lineSize := s.getLineSizeExcludingLFReverse()
+		if lineSize < 0 {
+			if err := s.readReverse(); err != nil {
+				s.scanErr = err
+				return false
+			}
+			continue
+		}
+		if lineSize == 0 {
+			continue
+		}
+		line := s.buffer[s.bufferLineEndPos-lineSize : s.bufferLineEndPos]
+		s.bufferLineEndPos -= lineSize
+		s.readerLineEndPos -= lineSize
+		s.token = trimCR(line)
+		return true
+	}
+}
+
+// Bytes returns the line most recently generated by a call to Scan, as a
+// slice of the Scanner's internal buffer. The slice is only valid until
+// the next call to Scan, which may overwrite or rearrange it.
+func (s *Scanner) Bytes() []byte {
+	return s.token
+}
+
+// Text returns the line most recently generated by a call to Scan as a
+// newly allocated string.
+func (s *Scanner) Text() string {
+	return string(s.token)
+}
+
+// Err returns the first non-EOF error that was encountered by the Scanner.
+func (s *Scanner) Err() error {
+	if s.scanErr == io.EOF {
+		return nil
+	}
+	return s.scanErr
+}
+
+// Buffer sets the initial buffer to use when scanning and the maximum
+// size of buffer that may be used. buf's capacity is reused when it is
+// large enough to hold max bytes; otherwise a new buffer is allocated.
+// Buffer must be called before scanning starts.
+func (s *Scanner) Buffer(buf []byte, max int) {
+	if cap(buf) < max {
+		buf = make([]byte, 0, max)
+	}
+	s.buffer = buf[:0:max]
+}
@@ -0,0 +1,150 @@
+package linescanner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+var (
+	ErrInvalidSize       = errors.New("size is invalid")
+	ErrInvalidShardCount = errors.New("shard count is invalid")
+)
+
+// ScanParallel splits reader into shards contiguous byte ranges and scans
+// each range with its own Scanner in its own goroutine, delivering lines
+// to fn alongside the index of the shard that produced them. A line that
+// straddles a shard boundary is read in full by the shard it starts in and
+// skipped by the shard it ends in, so every line is delivered exactly once.
+func ScanParallel(ctx context.Context, reader io.ReaderAt, size int64, shards int, fn func(shardIndex int, line string) error) error {
+	if size <= 0 {
+		return ErrInvalidSize
+	}
+	if shards <= 0 {
+		return ErrInvalidShardCount
+	}
+
+	shardSize := size / int64(shards)
+	if shardSize == 0 {
+		// More shards than bytes: give each byte its own shard and let the
+		// remaining shards fall past size, contributing nothing.
+		shardSize = 1
+	}
+	var wg sync.WaitGroup
+	errs := make([]error, shards)
+	for i := 0; i < shards; i++ {
+		start := int64(i) * shardSize
+		end := start + shardSize
+		if end > size || i == shards-1 {
+			end = size
+		}
+
+		wg.Add(1)
+		go func(shardIndex int, start, end int64) {
+			defer wg.Done()
+			if start >= size {
+				return
+			}
+			errs[shardIndex] = scanShard(ctx, reader, size, shardIndex, start, end, fn)
+		}(i, start, end)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanShard(ctx context.Context, reader io.ReaderAt, size int64, shardIndex int, start, end int64, fn func(shardIndex int, line string) error) error {
+	scanStart, err := alignShardStart(reader, size, start)
+	if err != nil {
+		return err
+	}
+	if scanStart >= end {
+		// Every line reachable from scanStart belongs to a later shard.
+		return nil
+	}
+
+	section := io.NewSectionReader(reader, scanStart, size-scanStart)
+	scanner := New(section, 0)
+	// Return blank lines as tokens instead of letting SplitLines skip them,
+	// so the end-of-shard check below also runs after each blank line and a
+	// run of them can't carry this shard into a line the next shard owns.
+	blank := false
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		blank = len(data) > 0 && data[0] == '\n'
+		if blank {
+			return 1, data[:0], nil
+		}
+		return SplitLines(data, atEOF)
+	})
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		lines, err := scanner.Line(1)
+		for _, line := range lines {
+			if blank {
+				continue
+			}
+			if err := fn(shardIndex, line); err != nil {
+				return err
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if scanStart+int64(scanner.Position()) >= end {
+			return nil
+		}
+	}
+}
+
+// alignShardStart returns the absolute offset of the first complete line at
+// or after start. When start already sits on a line boundary it is returned
+// unchanged. Otherwise start lands inside a line a preceding shard is
+// already responsible for (that shard reads past its own end to finish the
+// line), so the dangling remainder is located with a plain byte scan for
+// the next '\n' and skipped.
+func alignShardStart(reader io.ReaderAt, size int64, start int64) (int64, error) {
+	if start == 0 {
+		return 0, nil
+	}
+	var prev [1]byte
+	n, err := reader.ReadAt(prev[:], start-1)
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	if n == 1 && prev[0] == '\n' {
+		return start, nil
+	}
+	return nextLineFeed(reader, size, start)
+}
+
+// nextLineFeed returns the offset just past the first '\n' at or after
+// from, or size if none is found before the end of reader.
+func nextLineFeed(reader io.ReaderAt, size int64, from int64) (int64, error) {
+	buf := make([]byte, defaultChunkSize)
+	for pos := from; pos < size; {
+		n, err := reader.ReadAt(buf, pos)
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if idx := bytes.IndexByte(buf[:n], '\n'); idx >= 0 {
+			return pos + int64(idx) + 1, nil
+		}
+		if n == 0 || err == io.EOF {
+			break
+		}
+		pos += int64(n)
+	}
+	return size, nil
+}
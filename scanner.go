@@ -25,16 +25,32 @@ type Scanner struct {
 	chunk  []byte
 	buffer []byte
 
+	// split is only consulted in forward mode; reverse mode always uses
+	// '\n' delimited lines since it must search the buffer from the tail.
+	split SplitFunc
+
+	reverse bool
+
 	bufferLineStartPos int
 	readerPos          int
 	readerLineStartPos int
 
+	bufferLineEndPos int
+	readerLineEndPos int
+
 	backupBufferLineStartPos int
 	backupReaderPos          int
 	backupReaderLineStartPos int
 
-	endOfFile bool
-	endOfScan bool
+	backupBufferLineEndPos int
+	backupReaderLineEndPos int
+
+	endOfFile       bool
+	beginningOfFile bool
+	endOfScan       bool
+
+	token   []byte
+	scanErr error
 }
 
 func New(reader io.ReaderAt, position int) *Scanner {
@@ -55,38 +71,111 @@ func NewWithSize(reader io.ReaderAt, position int, chunkSize int, bufferSize int
 		reader:             reader,
 		chunk:              make([]byte, chunkSize),
 		buffer:             make([]byte, 0, bufferSize),
+		split:              SplitLines,
 		readerPos:          position,
 		readerLineStartPos: position,
 	}
 }
 
+// Split sets the split function for the Scanner. The default split
+// function is SplitLines. Split must be called before scanning starts,
+// and has no effect on a reverse Scanner.
+func (s *Scanner) Split(split SplitFunc) {
+	s.split = split
+}
+
+// NewReverse returns a Scanner that reads lines backwards from position
+// toward offset 0, so Line returns the lines closest to position first.
+func NewReverse(reader io.ReaderAt, position int) *Scanner {
+	return NewReverseWithSize(reader, position, defaultChunkSize, defaultBufferSize)
+}
+
+func NewReverseWithSize(reader io.ReaderAt, position int, chunkSize int, bufferSize int) *Scanner {
+	if chunkSize <= 0 {
+		panic(ErrInvalidChunkSize)
+	}
+	if bufferSize <= 0 {
+		panic(ErrInvalidBufferSize)
+	}
+	if chunkSize > bufferSize {
+		panic(ErrGreaterBufferSize)
+	}
+	return &Scanner{
+		reader:           reader,
+		chunk:            make([]byte, chunkSize),
+		buffer:           make([]byte, 0, bufferSize),
+		split:            SplitLines,
+		reverse:          true,
+		readerPos:        position,
+		readerLineEndPos: position,
+	}
+}
+
 func (s *Scanner) backupPosition() {
 	s.backupBufferLineStartPos = s.bufferLineStartPos
 	s.backupReaderPos = s.readerPos
-	s.backupBufferLineStartPos = s.readerLineStartPos
+	s.backupReaderLineStartPos = s.readerLineStartPos
 }
 
 func (s *Scanner) recoverPosition() {
 	s.bufferLineStartPos = s.backupBufferLineStartPos
 	s.readerPos = s.backupReaderPos
-	s.readerLineStartPos = s.backupBufferLineStartPos
+	s.readerLineStartPos = s.backupReaderLineStartPos
+}
+
+func (s *Scanner) backupPositionReverse() {
+	s.backupBufferLineEndPos = s.bufferLineEndPos
+	s.backupReaderPos = s.readerPos
+	s.backupReaderLineEndPos = s.readerLineEndPos
+}
+
+func (s *Scanner) recoverPositionReverse() {
+	s.bufferLineEndPos = s.backupBufferLineEndPos
+	s.readerPos = s.backupReaderPos
+	s.readerLineEndPos = s.backupReaderLineEndPos
 }
 
-func (s *Scanner) getLineSizeExcludingLF() int {
-	lineSize := bytes.IndexByte(s.buffer[s.bufferLineStartPos:], '\n')
-	if lineSize < 0 && s.endOfFile {
-		s.endOfScan = true
-		return len(s.buffer[s.bufferLineStartPos:])
+// nextToken runs s.split over the unconsumed portion of the buffer,
+// reading more of the reader as needed, until a token is produced or
+// scanning ends. It returns io.EOF once the split func reports there is
+// nothing left to scan, at which point s.endOfScan is also set so later
+// callers can short-circuit without consulting split again.
+func (s *Scanner) nextToken() ([]byte, error) {
+	for {
+		data := s.buffer[s.bufferLineStartPos:]
+		advance, token, err := s.split(data, s.endOfFile)
+		if err != nil {
+			return nil, err
+		}
+		if advance < 0 {
+			return nil, ErrNegativeAdvance
+		}
+		if advance > len(data) {
+			return nil, ErrAdvanceTooFar
+		}
+		if advance > 0 {
+			s.bufferLineStartPos += advance
+			s.readerLineStartPos += advance
+			if token != nil {
+				return token, nil
+			}
+			continue
+		}
+		if s.endOfFile {
+			s.endOfScan = true
+			return nil, io.EOF
+		}
+		if err := s.read(); err != nil {
+			return nil, err
+		}
 	}
-	return lineSize
 }
 
-func (s *Scanner) getLineExcludingCR(lineSize int) string {
-	line := s.buffer[s.bufferLineStartPos : s.bufferLineStartPos+lineSize]
+func trimCR(line []byte) []byte {
 	if line[len(line)-1] == '\r' {
-		return string(line[:len(line)-1])
+		return line[:len(line)-1]
 	}
-	return string(line)
+	return line
 }
 
 func (s *Scanner) rearrangeBuffer(n int) error {
@@ -120,7 +209,85 @@ func (s *Scanner) read() error {
 	return nil
 }
 
+// getLineSizeExcludingLFReverse looks for the start of the last unread line
+// in s.buffer[:s.bufferLineEndPos], searching from the tail of the file
+// toward the head.
+func (s *Scanner) getLineSizeExcludingLFReverse() int {
+	idx := bytes.LastIndexByte(s.buffer[:s.bufferLineEndPos], '\n')
+	if idx < 0 {
+		if s.beginningOfFile {
+			s.endOfScan = true
+			return s.bufferLineEndPos
+		}
+		return idx
+	}
+	return s.bufferLineEndPos - idx - 1
+}
+
+func (s *Scanner) rearrangeBufferReverse(n int) error {
+	used := s.bufferLineEndPos
+	if used+n > cap(s.buffer) {
+		return ErrBufferOverflow
+	}
+	if len(s.buffer) != used {
+		s.buffer = s.buffer[:used]
+	}
+	return nil
+}
+
+func (s *Scanner) readReverse() error {
+	if s.readerPos <= 0 {
+		s.beginningOfFile = true
+		return nil
+	}
+	readSize := len(s.chunk)
+	if readSize > s.readerPos {
+		readSize = s.readerPos
+	}
+	readerPos := s.readerPos - readSize
+	n, err := s.reader.ReadAt(s.chunk[:readSize], int64(readerPos))
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if n == 0 && err == io.EOF {
+		// This region is entirely past the reader's actual size (position
+		// started beyond it, or overestimated it by more than chunkSize).
+		// There's nothing here, but earlier regions may still hold real
+		// data, so just step back and let the next call try again, rather
+		// than treating this as BOF and abandoning the scan. Without the
+		// step back, s.readerPos never moves and lineReverse spins
+		// forever re-issuing the same out-of-range read.
+		s.readerPos = readerPos
+		if s.readerPos == 0 {
+			s.beginningOfFile = true
+		}
+		return nil
+	}
+	if n > 0 {
+		if err := s.rearrangeBufferReverse(n); err != nil {
+			return err
+		}
+		newLen := len(s.buffer) + n
+		s.buffer = s.buffer[:newLen]
+		copy(s.buffer[n:newLen], s.buffer[:newLen-n])
+		copy(s.buffer[:n], s.chunk[:n])
+		s.bufferLineEndPos += n
+		s.readerPos = readerPos
+	}
+	if s.readerPos == 0 {
+		s.beginningOfFile = true
+	}
+	return nil
+}
+
 func (s *Scanner) Line(lineCount int) (lines []string, err error) {
+	if s.reverse {
+		return s.lineReverse(lineCount)
+	}
+	return s.lineForward(lineCount)
+}
+
+func (s *Scanner) lineForward(lineCount int) (lines []string, err error) {
 	s.backupPosition()
 	if lineCount <= 0 {
 		return lines, ErrInvalidLineCount
@@ -129,24 +296,51 @@ func (s *Scanner) Line(lineCount int) (lines []string, err error) {
 		return lines, io.EOF
 	}
 	for {
-		lineSize := s.getLineSizeExcludingLF()
+		token, err := s.nextToken()
+		if err != nil {
+			if err == io.EOF {
+				return lines, io.EOF
+			}
+			s.recoverPosition()
+			return nil, err
+		}
+		lines = append(lines, string(token))
+		if len(lines) == lineCount {
+			return lines, nil
+		}
+	}
+}
+
+func (s *Scanner) lineReverse(lineCount int) (lines []string, err error) {
+	s.backupPositionReverse()
+	if lineCount <= 0 {
+		return lines, ErrInvalidLineCount
+	}
+	if s.endOfScan {
+		return lines, io.EOF
+	}
+	for {
+		if s.bufferLineEndPos > 0 && s.buffer[s.bufferLineEndPos-1] == '\n' {
+			s.bufferLineEndPos-- // skip line feed position
+			s.readerLineEndPos--
+		}
+		lineSize := s.getLineSizeExcludingLFReverse()
 		if lineSize < 0 {
-			if err := s.read(); err != nil {
-				s.recoverPosition()
+			if err := s.readReverse(); err != nil {
+				s.recoverPositionReverse()
 				return nil, err
 			}
 			continue
 		}
 		if lineSize > 0 {
-			lines = append(lines, s.getLineExcludingCR(lineSize))
-			s.bufferLineStartPos += lineSize
-			s.readerLineStartPos += lineSize
+			line := s.buffer[s.bufferLineEndPos-lineSize : s.bufferLineEndPos]
+			lines = append(lines, string(trimCR(line)))
+			s.bufferLineEndPos -= lineSize
+			s.readerLineEndPos -= lineSize
 		}
 		if s.endOfScan {
 			return lines, io.EOF
 		}
-		s.bufferLineStartPos++ // skip line feed position
-		s.readerLineStartPos++ // skip line feed position
 		if len(lines) == lineCount {
 			return lines, nil
 		}
@@ -154,5 +348,8 @@ func (s *Scanner) Line(lineCount int) (lines []string, err error) {
 }
 
 func (s *Scanner) Position() int {
+	if s.reverse {
+		return s.readerLineEndPos
+	}
 	return s.readerLineStartPos
 }
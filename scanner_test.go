@@ -0,0 +1,39 @@
+package linescanner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// TestReverseScanPastEOF guards against a regression where a reverse
+// Scanner given a position well beyond the reader's actual size (larger
+// than chunkSize, e.g. from an over-estimated file size) read zero lines
+// instead of the file's contents.
+func TestReverseScanPastEOF(t *testing.T) {
+	const lineCount = 50
+	var buf bytes.Buffer
+	want := make([]string, 0, lineCount)
+	for i := 0; i < lineCount; i++ {
+		line := fmt.Sprintf("line%d", i)
+		buf.WriteString(line + "\n")
+		want = append(want, line)
+	}
+	// Reverse order: Line returns lines closest to position first.
+	for i, j := 0, len(want)-1; i < j; i, j = i+1, j-1 {
+		want[i], want[j] = want[j], want[i]
+	}
+
+	r := bytes.NewReader(buf.Bytes())
+	s := NewReverseWithSize(r, 4096, 256, defaultBufferSize)
+
+	lines, err := s.Line(lineCount)
+	if err != nil && err != io.EOF {
+		t.Fatalf("Line returned unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Fatalf("Line() = %v, want %v", lines, want)
+	}
+}
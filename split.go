@@ -0,0 +1,187 @@
+package linescanner
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+var (
+	ErrNegativeAdvance             = errors.New("split func returned negative advance count")
+	ErrAdvanceTooFar               = errors.New("split func returned advance count beyond input")
+	ErrInvalidFixedSize            = errors.New("fixed size is invalid")
+	ErrInvalidDelim                = errors.New("delim is invalid")
+	ErrInvalidLengthPrefix         = errors.New("length prefix is invalid")
+	ErrInvalidLengthPrefixEncoding = errors.New("length prefix encoding is invalid")
+)
+
+// SplitFunc is the signature of the function used to tokenize the input.
+// It is given the portion of the buffer that has not yet been consumed,
+// which may be empty, and a flag, atEOF, that reports whether there is no
+// more data to feed it after this call. It returns the number of bytes to
+// advance the input and the token to deliver, if any, to the caller along
+// with an error, if any.
+//
+// Advancing the input past a blank token is done by returning a positive
+// advance with a nil token; this is how SplitLines skips blank lines.
+// Scanning stops if the function returns an error, in which case some of
+// the input may be left undelivered. A nil token with a nil error and an
+// advance of 0 when atEOF is true tells the Scanner there is nothing left
+// to scan.
+type SplitFunc func(data []byte, atEOF bool) (advance int, token []byte, err error)
+
+// SplitLines is the default SplitFunc. It splits on '\n', trims a trailing
+// '\r' from each line, and silently skips blank lines, matching the
+// behavior Line and Scan have always had.
+func SplitLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		if i == 0 {
+			return 1, nil, nil
+		}
+		return i + 1, trimCR(data[:i]), nil
+	}
+	if atEOF {
+		if len(data) == 0 {
+			return 0, nil, nil
+		}
+		return len(data), trimCR(data), nil
+	}
+	return 0, nil, nil
+}
+
+// SplitBytes returns a SplitFunc that splits on the single byte delim.
+func SplitBytes(delim byte) SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if i := bytes.IndexByte(data, delim); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF {
+			if len(data) == 0 {
+				return 0, nil, nil
+			}
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+// SplitDelim returns a SplitFunc that splits on delim, which may be more
+// than one byte long.
+func SplitDelim(delim []byte) SplitFunc {
+	if len(delim) == 0 {
+		panic(ErrInvalidDelim)
+	}
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if i := bytes.Index(data, delim); i >= 0 {
+			return i + len(delim), data[:i], nil
+		}
+		if atEOF {
+			if len(data) == 0 {
+				return 0, nil, nil
+			}
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+// SplitFixed returns a SplitFunc that delivers fixed-size tokens of n
+// bytes each. A final, short token at EOF is reported as
+// io.ErrUnexpectedEOF.
+func SplitFixed(n int) SplitFunc {
+	if n <= 0 {
+		panic(ErrInvalidFixedSize)
+	}
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if len(data) >= n {
+			return n, data[:n], nil
+		}
+		if atEOF && len(data) > 0 {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+		return 0, nil, nil
+	}
+}
+
+// LengthPrefixEncoding selects how SplitLengthPrefixed reads the length
+// that precedes each record.
+type LengthPrefixEncoding int
+
+const (
+	// VarintLengthPrefix reads the length as a protobuf-style unsigned
+	// varint, as used by recordio/protobuf-stream framing.
+	VarintLengthPrefix LengthPrefixEncoding = iota
+	// Uint32BigEndianLengthPrefix reads the length as a fixed 4-byte
+	// big-endian unsigned integer.
+	Uint32BigEndianLengthPrefix
+)
+
+// prefixedTotal adds a record's length to the size of its prefix, guarding
+// against the overflow a corrupt or crafted length would otherwise cause:
+// on a 32-bit int, a length near uint32's range already overflows, and on
+// any platform a malicious varint length up to 2^64-1 overflows int outright.
+// Either would turn total negative and panic the data[n:total] slice below.
+func prefixedTotal(prefixSize int, length uint64) (int, bool) {
+	if length > uint64(math.MaxInt-prefixSize) {
+		return 0, false
+	}
+	return prefixSize + int(length), true
+}
+
+// SplitLengthPrefixed returns a SplitFunc for records framed as a length
+// prefix, encoded per encoding, followed by that many bytes of payload.
+// A truncated final record at EOF is reported as io.ErrUnexpectedEOF. A
+// length prefix too large to be a real record size is reported as
+// ErrInvalidLengthPrefix rather than panicking.
+func SplitLengthPrefixed(encoding LengthPrefixEncoding) SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		switch encoding {
+		case VarintLengthPrefix:
+			length, n := binary.Uvarint(data)
+			if n == 0 {
+				if atEOF && len(data) > 0 {
+					return 0, nil, io.ErrUnexpectedEOF
+				}
+				return 0, nil, nil
+			}
+			if n < 0 {
+				return 0, nil, ErrInvalidLengthPrefix
+			}
+			total, ok := prefixedTotal(n, length)
+			if !ok {
+				return 0, nil, ErrInvalidLengthPrefix
+			}
+			if len(data) < total {
+				if atEOF {
+					return 0, nil, io.ErrUnexpectedEOF
+				}
+				return 0, nil, nil
+			}
+			return total, data[n:total], nil
+		case Uint32BigEndianLengthPrefix:
+			const prefixSize = 4
+			if len(data) < prefixSize {
+				if atEOF && len(data) > 0 {
+					return 0, nil, io.ErrUnexpectedEOF
+				}
+				return 0, nil, nil
+			}
+			length := binary.BigEndian.Uint32(data[:prefixSize])
+			total, ok := prefixedTotal(prefixSize, uint64(length))
+			if !ok {
+				return 0, nil, ErrInvalidLengthPrefix
+			}
+			if len(data) < total {
+				if atEOF {
+					return 0, nil, io.ErrUnexpectedEOF
+				}
+				return 0, nil, nil
+			}
+			return total, data[prefixSize:total], nil
+		default:
+			return 0, nil, ErrInvalidLengthPrefixEncoding
+		}
+	}
+}